@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -21,13 +23,26 @@ import (
 // Client provides a standardized HTTP client for service-to-service communication
 // with circuit breaker, retry, distributed tracing, and structured logging.
 type Client struct {
-	baseURL        string
-	httpClient     *http.Client
-	circuitBreaker *gobreaker.CircuitBreaker
-	retryBackoff   backoff.BackOff
-	tracer         trace.Tracer
-	logger         *zap.Logger
-	serviceName    string
+	httpClient      *http.Client
+	endpointsMu     sync.RWMutex
+	endpoints       []*Endpoint
+	newBreaker      func(name string) *gobreaker.CircuitBreaker
+	loadBalancer    LoadBalancer
+	newRetryBackoff func() *overridableBackOff
+	retryPolicy     RetryPolicy
+	codec           Codec
+	bulkhead        *bulkhead
+	rateLimiter     *rateLimiter
+	metrics         resilienceMetrics
+	tracer          trace.Tracer
+	logger          *zap.Logger
+	serviceName     string
+
+	healthStop chan struct{}
+	healthDone chan struct{}
+
+	resolveStop chan struct{}
+	resolveDone chan struct{}
 }
 
 // Config configures a service client.
@@ -37,6 +52,28 @@ type Config struct {
 	Timeout     time.Duration
 	Logger      *zap.Logger
 
+	// BaseURLs, when set, enables client-side load balancing across
+	// multiple endpoints instead of a single BaseURL. Each endpoint gets
+	// its own circuit breaker, so one flapping replica only trips its own
+	// breaker. Takes precedence over BaseURL.
+	BaseURLs []string
+	// LoadBalancer picks which endpoint serves each request from those
+	// that are healthy and not circuit-broken. Defaults to round-robin.
+	LoadBalancer LoadBalancer
+	// HealthPath, when set, is polled on every endpoint every
+	// HealthCheckInterval; endpoints that fail the probe are taken out of
+	// rotation until a probe succeeds again.
+	HealthPath          string
+	HealthCheckInterval time.Duration
+
+	// Resolver, when set, discovers the endpoint list in the background
+	// every ResolveInterval (default 30s) instead of using the static
+	// BaseURL/BaseURLs, which are ignored. Existing endpoints keep their
+	// circuit breaker state across a resolve; only additions and removals
+	// change.
+	Resolver        Resolver
+	ResolveInterval time.Duration
+
 	// Circuit breaker settings
 	MaxRequests uint32                      // Max requests in half-open state
 	Interval    time.Duration               // Time window for circuit breaker
@@ -49,6 +86,28 @@ type Config struct {
 	MaxElapsedTime      time.Duration // Maximum total retry time
 	Multiplier          float64       // Backoff multiplier
 	RandomizationFactor float64       // Randomization factor (0-1)
+
+	// RetryPolicy overrides the default retry classification, which
+	// otherwise classifies errors by type (see isRetryableError) and
+	// retries 5xx/429 responses, honoring Retry-After on 429/503. A
+	// non-zero delay returned from the policy overrides the next backoff
+	// interval.
+	RetryPolicy RetryPolicy
+
+	// Codec marshals request bodies and sets the request Content-Type.
+	// Defaults to JSONCodec. Override per-request with WithCodec.
+	Codec Codec
+
+	// MaxConcurrent caps the number of in-flight requests this client will
+	// allow (a bulkhead). Zero disables the limit. Callers beyond the cap
+	// queue for up to MaxWaitTime before being rejected.
+	MaxConcurrent int64
+	MaxWaitTime   time.Duration
+
+	// RequestsPerSecond, when positive, enables a client-side token-bucket
+	// rate limiter with the given refill rate and Burst capacity.
+	RequestsPerSecond float64
+	Burst             int
 }
 
 // DefaultConfig returns a config with sensible defaults.
@@ -87,44 +146,136 @@ func New(cfg *Config) *Client {
 		cfg.ServiceName = "unknown-service"
 	}
 
-	// Configure HTTP client
+	// Configure HTTP client. Transport is set explicitly (rather than left
+	// nil to fall back to http.DefaultTransport) so that Client.Use can
+	// wrap it with middleware.
 	httpClient := &http.Client{
-		Timeout: cfg.Timeout,
+		Timeout:   cfg.Timeout,
+		Transport: http.DefaultTransport,
 	}
 
-	// Configure circuit breaker
-	cb := gobreaker.NewCircuitBreaker(gobreaker.Settings{
-		Name:        cfg.ServiceName,
-		MaxRequests: cfg.MaxRequests,
-		Interval:    cfg.Interval,
-		Timeout:     cfg.TimeoutCB,
-		ReadyToTrip: cfg.ReadyToTrip,
-		OnStateChange: func(name string, from, to gobreaker.State) {
-			cfg.Logger.Info("circuit breaker state changed",
-				zap.String("service", name),
-				zap.String("from", from.String()),
-				zap.String("to", to.String()),
-			)
-		},
-	})
+	// newBreaker builds one circuit breaker per endpoint, so a single
+	// flapping replica trips only its own breaker. Kept as a closure so a
+	// Resolver-driven refresh can mint breakers for newly discovered
+	// endpoints the same way.
+	newBreaker := func(name string) *gobreaker.CircuitBreaker {
+		return gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:        name,
+			MaxRequests: cfg.MaxRequests,
+			Interval:    cfg.Interval,
+			Timeout:     cfg.TimeoutCB,
+			ReadyToTrip: cfg.ReadyToTrip,
+			OnStateChange: func(name string, from, to gobreaker.State) {
+				cfg.Logger.Info("circuit breaker state changed",
+					zap.String("endpoint", name),
+					zap.String("from", from.String()),
+					zap.String("to", to.String()),
+				)
+			},
+		})
+	}
+
+	var endpoints []*Endpoint
+	if cfg.Resolver == nil {
+		baseURLs := cfg.BaseURLs
+		if len(baseURLs) == 0 {
+			baseURLs = []string{cfg.BaseURL}
+		}
+		endpoints = buildEndpoints(cfg.ServiceName, baseURLs, newBreaker, nil)
+	} else {
+		resolveCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		urls, err := cfg.Resolver.Resolve(resolveCtx)
+		cancel()
+		if err != nil {
+			cfg.Logger.Error("initial service discovery resolve failed", zap.Error(err))
+		} else {
+			endpoints = buildEndpoints(cfg.ServiceName, urls, newBreaker, nil)
+		}
+	}
 
-	// Configure retry backoff
-	retryBackoff := backoff.NewExponentialBackOff()
-	retryBackoff.InitialInterval = cfg.InitialInterval
-	retryBackoff.MaxInterval = cfg.MaxInterval
-	retryBackoff.MaxElapsedTime = cfg.MaxElapsedTime
-	retryBackoff.Multiplier = cfg.Multiplier
-	retryBackoff.RandomizationFactor = cfg.RandomizationFactor
-
-	return &Client{
-		baseURL:        cfg.BaseURL,
-		httpClient:     httpClient,
-		circuitBreaker: cb,
-		retryBackoff:   retryBackoff,
-		tracer:         tracer,
-		logger:         cfg.Logger.Named("service-client").With(zap.String("service", cfg.ServiceName)),
-		serviceName:    cfg.ServiceName,
+	loadBalancer := cfg.LoadBalancer
+	if loadBalancer == nil {
+		loadBalancer = NewRoundRobinLoadBalancer()
+	}
+
+	// newRetryBackoff builds a fresh backoff for each request/attempt
+	// sequence. It must not be shared across concurrent requests: backoff.Retry
+	// calls Reset() at the start of every call and NextBackOff() tracks a
+	// single elapsed-time clock, so two concurrent requests sharing one
+	// instance would reset and consume each other's schedule (including any
+	// Retry-After override from SetNextInterval).
+	newRetryBackoff := func() *overridableBackOff {
+		expBackoff := backoff.NewExponentialBackOff()
+		expBackoff.InitialInterval = cfg.InitialInterval
+		expBackoff.MaxInterval = cfg.MaxInterval
+		expBackoff.MaxElapsedTime = cfg.MaxElapsedTime
+		expBackoff.Multiplier = cfg.Multiplier
+		expBackoff.RandomizationFactor = cfg.RandomizationFactor
+		return newOverridableBackOff(expBackoff)
+	}
+
+	retryPolicy := cfg.RetryPolicy
+	if retryPolicy == nil {
+		retryPolicy = defaultRetryPolicy
+	}
+
+	codec := cfg.Codec
+	if codec == nil {
+		codec = JSONCodec
+	}
+
+	c := &Client{
+		httpClient:      httpClient,
+		endpoints:       endpoints,
+		newBreaker:      newBreaker,
+		loadBalancer:    loadBalancer,
+		newRetryBackoff: newRetryBackoff,
+		retryPolicy:     retryPolicy,
+		codec:           codec,
+		bulkhead:        newBulkhead(cfg.MaxConcurrent, cfg.MaxWaitTime),
+		rateLimiter:     newRateLimiter(cfg.RequestsPerSecond, cfg.Burst),
+		metrics:         newResilienceMetrics(otel.Meter("shared-service-client")),
+		tracer:          tracer,
+		logger:          cfg.Logger.Named("service-client").With(zap.String("service", cfg.ServiceName)),
+		serviceName:     cfg.ServiceName,
+	}
+
+	if cfg.HealthPath != "" {
+		interval := cfg.HealthCheckInterval
+		if interval <= 0 {
+			interval = 10 * time.Second
+		}
+		c.startHealthChecks(cfg.HealthPath, interval)
 	}
+
+	if cfg.Resolver != nil {
+		interval := cfg.ResolveInterval
+		if interval <= 0 {
+			interval = 30 * time.Second
+		}
+		c.startResolving(cfg.Resolver, interval)
+	}
+
+	return c
+}
+
+// buildEndpoints constructs an Endpoint per base URL, reusing an endpoint
+// (and so its circuit breaker state) from existing when its base URL is
+// unchanged, and minting a new breaker via newBreaker otherwise.
+func buildEndpoints(serviceName string, baseURLs []string, newBreaker func(name string) *gobreaker.CircuitBreaker, existing map[string]*Endpoint) []*Endpoint {
+	endpoints := make([]*Endpoint, 0, len(baseURLs))
+	for _, u := range baseURLs {
+		if e, ok := existing[u]; ok {
+			endpoints = append(endpoints, e)
+			continue
+		}
+		name := serviceName
+		if len(baseURLs) > 1 {
+			name = fmt.Sprintf("%s[%s]", serviceName, u)
+		}
+		endpoints = append(endpoints, newEndpoint(u, newBreaker(name)))
+	}
+	return endpoints
 }
 
 // Response wraps an HTTP response with body.
@@ -139,19 +290,25 @@ func (c *Client) Get(ctx context.Context, path string, headers map[string]string
 	return c.doRequest(ctx, http.MethodGet, path, nil, headers)
 }
 
-// Post performs a POST request with retry and circuit breaker.
-func (c *Client) Post(ctx context.Context, path string, body interface{}, headers map[string]string) (*Response, error) {
-	return c.doRequest(ctx, http.MethodPost, path, body, headers)
+// Post performs a POST request with retry and circuit breaker. By default
+// the body is marshalled with the Client's Codec (JSON unless configured
+// otherwise); pass WithCodec to use a different one for this call.
+func (c *Client) Post(ctx context.Context, path string, body interface{}, headers map[string]string, opts ...RequestOption) (*Response, error) {
+	return c.doRequest(ctx, http.MethodPost, path, body, headers, opts...)
 }
 
-// Put performs a PUT request with retry and circuit breaker.
-func (c *Client) Put(ctx context.Context, path string, body interface{}, headers map[string]string) (*Response, error) {
-	return c.doRequest(ctx, http.MethodPut, path, body, headers)
+// Put performs a PUT request with retry and circuit breaker. By default
+// the body is marshalled with the Client's Codec (JSON unless configured
+// otherwise); pass WithCodec to use a different one for this call.
+func (c *Client) Put(ctx context.Context, path string, body interface{}, headers map[string]string, opts ...RequestOption) (*Response, error) {
+	return c.doRequest(ctx, http.MethodPut, path, body, headers, opts...)
 }
 
-// Patch performs a PATCH request with retry and circuit breaker.
-func (c *Client) Patch(ctx context.Context, path string, body interface{}, headers map[string]string) (*Response, error) {
-	return c.doRequest(ctx, http.MethodPatch, path, body, headers)
+// Patch performs a PATCH request with retry and circuit breaker. By default
+// the body is marshalled with the Client's Codec (JSON unless configured
+// otherwise); pass WithCodec to use a different one for this call.
+func (c *Client) Patch(ctx context.Context, path string, body interface{}, headers map[string]string, opts ...RequestOption) (*Response, error) {
+	return c.doRequest(ctx, http.MethodPatch, path, body, headers, opts...)
 }
 
 // Delete performs a DELETE request with retry and circuit breaker.
@@ -159,96 +316,131 @@ func (c *Client) Delete(ctx context.Context, path string, headers map[string]str
 	return c.doRequest(ctx, http.MethodDelete, path, nil, headers)
 }
 
-func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}, headers map[string]string) (*Response, error) {
+func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}, headers map[string]string, opts ...RequestOption) (*Response, error) {
+	reqOpts := requestOptions{codec: c.codec}
+	for _, opt := range opts {
+		opt(&reqOpts)
+	}
+
+	endpoint, err := c.selectEndpoint()
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(&endpoint.inFlight, 1)
+	defer atomic.AddInt64(&endpoint.inFlight, -1)
+
+	// Resilience policies run in a fixed order ahead of the circuit
+	// breaker and retry loop below: rate-limit, then bulkhead.
+	if err := c.rateLimiter.wait(ctx); err != nil {
+		c.metrics.rateLimitRejections.Add(ctx, 1)
+		return nil, err
+	}
+	release, err := c.bulkhead.acquire(ctx)
+	if err != nil {
+		c.metrics.bulkheadRejections.Add(ctx, 1)
+		return nil, err
+	}
+	defer release()
+
 	// Create span for distributed tracing
 	ctx, span := c.tracer.Start(ctx, fmt.Sprintf("%s %s", method, path),
 		trace.WithAttributes(
 			attribute.String("http.method", method),
-			attribute.String("http.url", c.baseURL+path),
+			attribute.String("http.url", endpoint.BaseURL+path),
 			attribute.String("service.name", c.serviceName),
 		))
 	defer span.End()
 
-	// Prepare request body
-	var bodyReader io.Reader
+	// Marshal the body once: the resulting bytes are immutable and can be
+	// wrapped in a fresh reader for every retry attempt.
+	var bodyBytes []byte
 	if body != nil {
-		bodyBytes, err := json.Marshal(body)
+		var err error
+		bodyBytes, err = reqOpts.codec.Marshal(body)
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, err.Error())
 			return nil, fmt.Errorf("marshal request body: %w", err)
 		}
-		bodyReader = bytes.NewReader(bodyBytes)
 	}
 
-	// Create HTTP request
-	url := c.baseURL + path
-	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		return nil, fmt.Errorf("create request: %w", err)
+	url := endpoint.BaseURL + path
+
+	// Generate the Idempotency-Key once per call, not per attempt: if it
+	// were left to IdempotencyKeyMiddleware to fill in on each freshly built
+	// request, every retry would get a different random key, defeating the
+	// server-side dedup the header exists for.
+	idempotencyKey := headers["Idempotency-Key"]
+	if idempotencyKey == "" {
+		switch method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			key, err := newIdempotencyKey()
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return nil, fmt.Errorf("generate idempotency key: %w", err)
+			}
+			idempotencyKey = key
+		}
 	}
 
-	// Set default headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
-	// Set custom headers
-	for k, v := range headers {
-		req.Header.Set(k, v)
+	// newRequest builds a fresh *http.Request (and body reader) for each
+	// attempt. Reusing a single request/body across retries would send an
+	// empty body on any attempt after the first, since the previous
+	// attempt already drained it.
+	newRequest := func() (*http.Request, error) {
+		var bodyReader io.Reader
+		if bodyBytes != nil {
+			bodyReader = bytes.NewReader(bodyBytes)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Content-Type", reqOpts.codec.ContentType())
+		req.Header.Set("Accept", reqOpts.codec.ContentType())
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		if idempotencyKey != "" {
+			req.Header.Set("Idempotency-Key", idempotencyKey)
+		}
+		return req, nil
 	}
 
 	// Log request
 	c.logger.Debug("service request",
 		zap.String("method", method),
 		zap.String("url", url),
-		zap.Any("headers", req.Header),
+		zap.Any("headers", headers),
 	)
 
-	// Execute with circuit breaker and retry
+	// Execute with circuit breaker and retry. retryBackoff is built fresh for
+	// this call so concurrent requests don't share (and so stomp on) each
+	// other's elapsed-time clock or Retry-After override.
+	retryBackoff := c.newRetryBackoff()
 	var resp *Response
 	err = backoff.Retry(func() error {
-		// Execute through circuit breaker
-		result, err := c.circuitBreaker.Execute(func() (interface{}, error) {
-			httpResp, err := c.httpClient.Do(req)
-			if err != nil {
-				span.RecordError(err)
-				return nil, err
-			}
-
-			// Read response body
-			respBody, readErr := io.ReadAll(httpResp.Body)
-			httpResp.Body.Close()
-			if readErr != nil {
-				return nil, fmt.Errorf("read response: %w", readErr)
-			}
-
-			// Check if we should retry based on status code
-			if httpResp.StatusCode >= 500 || httpResp.StatusCode == 429 {
-				return nil, fmt.Errorf("retryable status %d: %s", httpResp.StatusCode, string(respBody))
-			}
-
-			resp = &Response{
-				StatusCode: httpResp.StatusCode,
-				Headers:    httpResp.Header,
-				Body:       respBody,
-			}
-
-			return resp, nil
-		})
-
+		req, err := newRequest()
 		if err != nil {
-			// Check if error is retryable
-			if !isRetryableError(err) {
-				return backoff.Permanent(err)
-			}
-			return err
+			return backoff.Permanent(err)
 		}
 
-		resp = result.(*Response)
-		return nil
-	}, backoff.WithContext(c.retryBackoff, ctx))
+		// Execute through circuit breaker
+		result, _, retry, delay, execErr := c.executeOnce(endpoint, req)
+		if execErr == nil {
+			resp = result
+			return nil
+		}
+		span.RecordError(execErr)
+		if !retry {
+			return backoff.Permanent(execErr)
+		}
+		if delay > 0 {
+			retryBackoff.SetNextInterval(delay)
+		}
+		return execErr
+	}, backoff.WithContext(retryBackoff, ctx))
 
 	if err != nil {
 		span.RecordError(err)
@@ -278,22 +470,89 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 	return resp, nil
 }
 
-// isRetryableError determines if an error should be retried.
-func isRetryableError(err error) bool {
-	if err == nil {
-		return false
+// selectEndpoint filters the client's endpoints down to those that are
+// healthy and not circuit-broken, then asks the configured LoadBalancer to
+// pick one.
+func (c *Client) selectEndpoint() (*Endpoint, error) {
+	c.endpointsMu.RLock()
+	endpoints := c.endpoints
+	c.endpointsMu.RUnlock()
+
+	candidates := make([]*Endpoint, 0, len(endpoints))
+	for _, e := range endpoints {
+		if e.available() {
+			candidates = append(candidates, e)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("serviceclient: no available endpoints for service %q", c.serviceName)
 	}
+	return c.loadBalancer.Next(candidates)
+}
+
+// attemptFunc performs a single attempt, returning the raw *http.Response
+// received (nil if the attempt failed before one arrived), an opaque success
+// value to hand back to the caller once retryPolicy has cleared it, and any
+// transport/read error encountered.
+type attemptFunc func() (httpResp *http.Response, value interface{}, err error)
+
+// executeWithVerdict runs fn through breaker, letting policy alone decide
+// both the circuit breaker's pass/fail verdict and whether the caller should
+// retry: a response policy declines to retry is returned as success
+// regardless of its status code. This is shared by executeOnce and Stream so
+// the verdict logic only has to be gotten right in one place.
+func executeWithVerdict(breaker *gobreaker.CircuitBreaker, policy RetryPolicy, fn attemptFunc) (value interface{}, httpResp *http.Response, retry bool, delay time.Duration, err error) {
+	result, cbErr := breaker.Execute(func() (interface{}, error) {
+		hr, v, attemptErr := fn()
+		httpResp = hr
+		if attemptErr != nil {
+			retry, delay = policy(hr, attemptErr)
+			return nil, attemptErr
+		}
+		if retry, delay = policy(hr, nil); retry {
+			return nil, fmt.Errorf("retryable status %d", hr.StatusCode)
+		}
+		return v, nil
+	})
 
-	// Network errors are retryable
-	if err.Error() == "context deadline exceeded" ||
-		err.Error() == "context canceled" ||
-		err.Error() == "connection refused" ||
-		err.Error() == "connection reset" {
-		return true
+	if cbErr != nil {
+		return nil, httpResp, retry, delay, cbErr
 	}
+	return result, httpResp, false, 0, nil
+}
+
+// executeOnce performs a single attempt of req through the circuit breaker.
+// c.retryPolicy alone decides both whether the attempt counts as a circuit
+// breaker failure and whether the built Response is handed back to the
+// caller: a response the policy declines to retry (regardless of its status
+// code) is returned as a success, exactly like any other response would be.
+// retry and delay are the policy's verdict, already applied; err is non-nil
+// only when the caller should not treat resp as usable (a transport failure,
+// a short read, or a status the policy wants retried).
+func (c *Client) executeOnce(endpoint *Endpoint, req *http.Request) (resp *Response, httpResp *http.Response, retry bool, delay time.Duration, err error) {
+	value, httpResp, retry, delay, err := executeWithVerdict(endpoint.breaker, c.retryPolicy, func() (*http.Response, interface{}, error) {
+		hr, doErr := c.httpClient.Do(req)
+		if doErr != nil {
+			return nil, nil, doErr
+		}
 
-	// HTTP 5xx and 429 are retryable (handled in doRequest)
-	return false
+		respBody, readErr := io.ReadAll(hr.Body)
+		hr.Body.Close()
+		if readErr != nil {
+			return hr, nil, fmt.Errorf("read response: %w", readErr)
+		}
+
+		return hr, &Response{
+			StatusCode: hr.StatusCode,
+			Headers:    hr.Header,
+			Body:       respBody,
+		}, nil
+	})
+
+	if err != nil {
+		return nil, httpResp, retry, delay, err
+	}
+	return value.(*Response), httpResp, false, 0, nil
 }
 
 // DecodeJSON unmarshals the response body into the provided value.
@@ -301,6 +560,15 @@ func (r *Response) DecodeJSON(v interface{}) error {
 	return json.Unmarshal(r.Body, v)
 }
 
+// Decode unmarshals the response body into the provided value, selecting a
+// Codec based on the response's Content-Type header (falling back to JSON
+// when the type is empty or unrecognized). Use RegisterCodec to teach it
+// about additional wire formats.
+func (r *Response) Decode(v interface{}) error {
+	codec := codecForContentType(r.Headers.Get("Content-Type"))
+	return codec.Unmarshal(r.Body, v)
+}
+
 // IsSuccess returns true if status code is 2xx.
 func (r *Response) IsSuccess() bool {
 	return r.StatusCode >= 200 && r.StatusCode < 300
@@ -0,0 +1,212 @@
+package serviceclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func testClient(t *testing.T, baseURL string, configure func(*Config)) *Client {
+	t.Helper()
+	cfg := DefaultConfig(baseURL, "test-service", zap.NewNop())
+	cfg.InitialInterval = time.Millisecond
+	cfg.MaxInterval = 5 * time.Millisecond
+	cfg.MaxElapsedTime = time.Second
+	if configure != nil {
+		configure(cfg)
+	}
+	return New(cfg)
+}
+
+// TestDoRequestReplaysBodyAcrossRetries guards against the regression where
+// doRequest built its *http.Request once outside the retry loop: a body
+// already drained by a failed first attempt was then resent empty on the
+// second, failing with "ContentLength=N with Body length 0" instead of
+// succeeding.
+func TestDoRequestReplaysBodyAcrossRetries(t *testing.T) {
+	var attempts int32
+	var bodies []string
+	var mu sync.Mutex
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&attempts, 1)
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("attempt %d: read body: %v", n, err)
+		}
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+
+		// Force a fresh connection on retry by closing the connection
+		// after a failing first attempt, the same condition the live bug
+		// report reproduced (Connection: close on a 503).
+		w.Header().Set("Connection", "close")
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv.URL, nil)
+	resp, err := c.Post(context.Background(), "/", map[string]string{"hello": "world"}, nil)
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if !resp.IsSuccess() {
+		t.Fatalf("status = %d, want success", resp.StatusCode)
+	}
+	if atomic.LoadInt32(&attempts) != 2 {
+		t.Fatalf("attempts = %d, want 2", attempts)
+	}
+	if bodies[0] != bodies[1] || bodies[0] == "" {
+		t.Fatalf("bodies = %v, want both attempts to carry the same non-empty body", bodies)
+	}
+}
+
+// TestDoRequestIdempotencyKeyStableAcrossRetries guards against the
+// regression where doRequest's per-attempt request rebuild caused
+// IdempotencyKeyMiddleware to mint a fresh random key on every retry.
+func TestDoRequestIdempotencyKeyStableAcrossRetries(t *testing.T) {
+	var keys []string
+	var mu sync.Mutex
+	attempt := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempt++
+		mu.Lock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		mu.Unlock()
+		if attempt < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv.URL, nil)
+	c.Use(IdempotencyKeyMiddleware())
+
+	resp, err := c.Post(context.Background(), "/", map[string]string{"a": "b"}, nil)
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if !resp.IsSuccess() {
+		t.Fatalf("status = %d, want success", resp.StatusCode)
+	}
+	if len(keys) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(keys))
+	}
+	for i, k := range keys {
+		if k == "" {
+			t.Fatalf("attempt %d had no Idempotency-Key", i+1)
+		}
+		if k != keys[0] {
+			t.Fatalf("keys = %v, want the same key on every retry", keys)
+		}
+	}
+}
+
+// TestExecuteOnceSurfacesResponseWhenPolicyDeclinesRetry guards against the
+// regression where a status code retryPolicy chose not to retry (but that
+// fell inside the old hardcoded >=500/429 breaker-failure check) discarded
+// the built Response instead of handing it back to the caller.
+func TestExecuteOnceSurfacesResponseWhenPolicyDeclinesRetry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":"rate limited"}`))
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv.URL, func(cfg *Config) {
+		cfg.RetryPolicy = func(resp *http.Response, err error) (bool, time.Duration) {
+			return false, 0 // caller wants to inspect the 429 body itself
+		}
+	})
+
+	resp, err := c.Get(context.Background(), "/", nil)
+	if err != nil {
+		t.Fatalf("Get returned an error instead of the 429 Response: %v", err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", resp.StatusCode)
+	}
+	if string(resp.Body) != `{"error":"rate limited"}` {
+		t.Fatalf("body = %q, want the rate-limit payload preserved", resp.Body)
+	}
+}
+
+// TestExecuteOnceRetries5xxByDefault confirms the default behavior (5xx
+// retried, no custom policy) is unchanged by the refactor.
+func TestExecuteOnceRetries5xxByDefault(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv.URL, nil)
+	resp, err := c.Get(context.Background(), "/", nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !resp.IsSuccess() {
+		t.Fatalf("status = %d, want success", resp.StatusCode)
+	}
+	if atomic.LoadInt32(&attempts) != 2 {
+		t.Fatalf("attempts = %d, want a retry after the 500", attempts)
+	}
+}
+
+// TestConcurrentRequestsDoNotShareBackoffElapsedTime guards against the
+// regression where one shared overridableBackOff/elapsed-time clock on
+// Client let concurrent requests extend each other's MaxElapsedTime well
+// past what each individually configured.
+func TestConcurrentRequestsDoNotShareBackoffElapsedTime(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv.URL, func(cfg *Config) {
+		cfg.InitialInterval = 20 * time.Millisecond
+		cfg.MaxInterval = 20 * time.Millisecond
+		cfg.MaxElapsedTime = 150 * time.Millisecond
+		cfg.RandomizationFactor = 0
+	})
+
+	const n = 20
+	var wg sync.WaitGroup
+	durations := make([]time.Duration, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			start := time.Now()
+			_, _ = c.Get(context.Background(), "/", nil)
+			durations[i] = time.Since(start)
+		}(i)
+	}
+	wg.Wait()
+
+	const budget = 150*time.Millisecond + 400*time.Millisecond // generous slack for scheduling jitter
+	for i, d := range durations {
+		if d > budget {
+			t.Fatalf("request %d ran for %v, want well under %v (MaxElapsedTime should not have been extended by concurrent requests)", i, d, budget)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package serviceclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec marshals and unmarshals request/response bodies for a particular
+// wire format, and reports the Content-Type that identifies it.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	ContentType() string
+}
+
+// jsonCodec encodes bodies as JSON.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) ContentType() string                        { return "application/json" }
+
+// protobufCodec encodes bodies as binary protocol buffers. Values passed to
+// Marshal/Unmarshal must implement proto.Message.
+type protobufCodec struct{}
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("serviceclient: protobuf codec requires a proto.Message, got %T", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("serviceclient: protobuf codec requires a proto.Message, got %T", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+func (protobufCodec) ContentType() string { return "application/x-protobuf" }
+
+// msgpackCodec encodes bodies as MessagePack.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error)      { return msgpack.Marshal(v) }
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error { return msgpack.Unmarshal(data, v) }
+func (msgpackCodec) ContentType() string                        { return "application/x-msgpack" }
+
+// Built-in codecs. JSONCodec is used when a Client or request does not
+// specify one.
+var (
+	JSONCodec     Codec = jsonCodec{}
+	ProtobufCodec Codec = protobufCodec{}
+	MsgpackCodec  Codec = msgpackCodec{}
+)
+
+// codecsByContentType maps a response Content-Type to the codec used to
+// decode it in Response.Decode. RegisterCodec adds or overrides an entry.
+var codecsByContentType = map[string]Codec{
+	JSONCodec.ContentType():     JSONCodec,
+	ProtobufCodec.ContentType(): ProtobufCodec,
+	MsgpackCodec.ContentType():  MsgpackCodec,
+}
+
+// RegisterCodec makes codec available to Response.Decode for the given
+// Content-Type, in addition to the built-in JSON, protobuf, and msgpack
+// codecs.
+func RegisterCodec(contentType string, codec Codec) {
+	codecsByContentType[contentType] = codec
+}
+
+// requestOptions holds per-request overrides.
+type requestOptions struct {
+	codec Codec
+}
+
+// RequestOption customizes a single Client method call, overriding
+// Client-level defaults.
+type RequestOption func(*requestOptions)
+
+// WithCodec overrides the Codec used to marshal the request body (and, by
+// virtue of its Content-Type, the hint used to decode the response) for a
+// single request.
+func WithCodec(codec Codec) RequestOption {
+	return func(o *requestOptions) { o.codec = codec }
+}
+
+// codecForContentType returns the codec registered for the response's
+// Content-Type header, falling back to JSONCodec when the type is empty or
+// unrecognized.
+func codecForContentType(contentType string) Codec {
+	if contentType != "" {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+			if codec, ok := codecsByContentType[mediaType]; ok {
+				return codec
+			}
+		}
+	}
+	return JSONCodec
+}
@@ -0,0 +1,167 @@
+package serviceclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Resolver discovers the set of base URLs a Client should load balance
+// across. A Client configured with a Resolver polls it in the background
+// (every Config.ResolveInterval) instead of using a static BaseURL/BaseURLs,
+// so new replicas are picked up and dead ones dropped without a restart.
+type Resolver interface {
+	Resolve(ctx context.Context) ([]string, error)
+}
+
+// DNSSRVResolver resolves base URLs from DNS SRV records, the mechanism
+// Kubernetes headless Services publish their pod IPs and ports under.
+type DNSSRVResolver struct {
+	// Service and Proto are the SRV record's service and protocol labels
+	// (e.g. "http", "tcp").
+	Service string
+	Proto   string
+	// Name is the DNS name to look up, e.g.
+	// "my-svc.my-namespace.svc.cluster.local".
+	Name string
+	// Scheme prefixes each resolved base URL; defaults to "http".
+	Scheme string
+	// Resolver performs the lookup; defaults to net.DefaultResolver.
+	Resolver *net.Resolver
+}
+
+// Resolve implements Resolver.
+func (r *DNSSRVResolver) Resolve(ctx context.Context) ([]string, error) {
+	resolver := r.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	scheme := r.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	_, srvs, err := resolver.LookupSRV(ctx, r.Service, r.Proto, r.Name)
+	if err != nil {
+		return nil, fmt.Errorf("serviceclient: lookup SRV records for %q: %w", r.Name, err)
+	}
+
+	urls := make([]string, 0, len(srvs))
+	for _, srv := range srvs {
+		target := strings.TrimSuffix(srv.Target, ".")
+		urls = append(urls, fmt.Sprintf("%s://%s:%d", scheme, target, srv.Port))
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("serviceclient: no SRV records found for %q", r.Name)
+	}
+	return urls, nil
+}
+
+// ConsulResolver resolves base URLs from a Consul agent's catalog for a
+// given service, via Consul's HTTP API directly so this package doesn't
+// need to depend on the Consul API client module.
+type ConsulResolver struct {
+	// Address is the Consul HTTP API base, e.g. "http://127.0.0.1:8500".
+	Address string
+	// Service is the Consul service name to look up.
+	Service string
+	// Scheme prefixes each resolved base URL; defaults to "http".
+	Scheme string
+	// HTTPClient performs the catalog request; defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+type consulServiceEntry struct {
+	Address        string
+	ServiceAddress string
+	ServicePort    int
+}
+
+// Resolve implements Resolver.
+func (r *ConsulResolver) Resolve(ctx context.Context) ([]string, error) {
+	client := r.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	scheme := r.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	catalogURL := strings.TrimRight(r.Address, "/") + "/v1/catalog/service/" + r.Service
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, catalogURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("serviceclient: build consul catalog request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("serviceclient: query consul catalog: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("serviceclient: consul catalog returned status %d", resp.StatusCode)
+	}
+
+	var entries []consulServiceEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("serviceclient: decode consul catalog response: %w", err)
+	}
+
+	urls := make([]string, 0, len(entries))
+	for _, e := range entries {
+		addr := e.ServiceAddress
+		if addr == "" {
+			addr = e.Address
+		}
+		urls = append(urls, fmt.Sprintf("%s://%s:%d", scheme, addr, e.ServicePort))
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("serviceclient: consul returned no instances for service %q", r.Service)
+	}
+	return urls, nil
+}
+
+// EtcdGetter is the minimal surface this package needs from an etcd client
+// to resolve endpoints registered under a key prefix. It exists so this
+// module isn't forced to depend on go.etcd.io/etcd/client/v3 and its
+// transitive gRPC dependency tree; wrap a real *clientv3.Client's KV.Get
+// (called with clientv3.WithPrefix()) to satisfy it.
+type EtcdGetter interface {
+	// Get returns the value of every key under prefix, one entry per key,
+	// each expected to contain a single base URL.
+	Get(ctx context.Context, prefix string) (values [][]byte, err error)
+}
+
+// EtcdResolver resolves base URLs from values stored under an etcd key
+// prefix, following the common registry convention where each service
+// instance registers a key whose value is its own base URL.
+type EtcdResolver struct {
+	Getter EtcdGetter
+	Prefix string
+}
+
+// Resolve implements Resolver.
+func (r *EtcdResolver) Resolve(ctx context.Context) ([]string, error) {
+	values, err := r.Getter.Get(ctx, r.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("serviceclient: resolve from etcd prefix %q: %w", r.Prefix, err)
+	}
+
+	urls := make([]string, 0, len(values))
+	for _, v := range values {
+		if len(v) == 0 {
+			continue
+		}
+		urls = append(urls, string(v))
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("serviceclient: no endpoints found under etcd prefix %q", r.Prefix)
+	}
+	return urls, nil
+}
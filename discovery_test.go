@@ -0,0 +1,91 @@
+package serviceclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConsulResolverResolve(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/catalog/service/my-svc" {
+			t.Fatalf("unexpected path %q", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"ServiceAddress":"10.0.0.1","ServicePort":8080},{"Address":"10.0.0.2","ServicePort":8081}]`))
+	}))
+	defer srv.Close()
+
+	r := &ConsulResolver{Address: srv.URL, Service: "my-svc"}
+	urls, err := r.Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	want := []string{"http://10.0.0.1:8080", "http://10.0.0.2:8081"}
+	if len(urls) != len(want) {
+		t.Fatalf("urls = %v, want %v", urls, want)
+	}
+	for i := range want {
+		if urls[i] != want[i] {
+			t.Fatalf("urls = %v, want %v", urls, want)
+		}
+	}
+}
+
+func TestConsulResolverNoInstances(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	r := &ConsulResolver{Address: srv.URL, Service: "my-svc"}
+	if _, err := r.Resolve(context.Background()); err == nil {
+		t.Fatal("expected an error when Consul returns no instances")
+	}
+}
+
+func TestConsulResolverNonOKStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	r := &ConsulResolver{Address: srv.URL, Service: "my-svc"}
+	if _, err := r.Resolve(context.Background()); err == nil {
+		t.Fatal("expected an error on a non-200 catalog response")
+	}
+}
+
+type fakeEtcdGetter struct {
+	values [][]byte
+	err    error
+}
+
+func (f *fakeEtcdGetter) Get(ctx context.Context, prefix string) ([][]byte, error) {
+	return f.values, f.err
+}
+
+func TestEtcdResolverResolve(t *testing.T) {
+	r := &EtcdResolver{
+		Getter: &fakeEtcdGetter{values: [][]byte{[]byte("http://10.0.0.1:8080"), []byte("http://10.0.0.2:8080"), {}}},
+		Prefix: "/services/my-svc/",
+	}
+	urls, err := r.Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	want := []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"}
+	if len(urls) != len(want) {
+		t.Fatalf("urls = %v, want %v (empty values should be skipped)", urls, want)
+	}
+}
+
+func TestEtcdResolverNoEndpoints(t *testing.T) {
+	r := &EtcdResolver{Getter: &fakeEtcdGetter{}, Prefix: "/services/my-svc/"}
+	if _, err := r.Resolve(context.Background()); err == nil {
+		t.Fatal("expected an error when etcd has no keys under the prefix")
+	}
+}
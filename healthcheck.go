@@ -0,0 +1,82 @@
+package serviceclient
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// healthCheckTimeout bounds each periodic probe. Probes go through their own
+// http.Client rather than c.httpClient so that health checks have this short
+// timeout regardless of the timeout configured for normal requests.
+var healthCheckTimeout = 5 * time.Second
+
+// startHealthChecks begins polling path on every endpoint every interval,
+// marking an endpoint unhealthy (and so out of LoadBalancer rotation) when
+// a probe fails or returns a non-2xx status, and healthy again once a probe
+// succeeds.
+func (c *Client) startHealthChecks(path string, interval time.Duration) {
+	c.healthStop = make(chan struct{})
+	c.healthDone = make(chan struct{})
+
+	probeClient := &http.Client{Timeout: healthCheckTimeout}
+
+	go func() {
+		defer close(c.healthDone)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.healthStop:
+				return
+			case <-ticker.C:
+				c.endpointsMu.RLock()
+				endpoints := c.endpoints
+				c.endpointsMu.RUnlock()
+				for _, e := range endpoints {
+					c.probeEndpoint(probeClient, e, path)
+				}
+			}
+		}
+	}()
+}
+
+func (c *Client) probeEndpoint(probeClient *http.Client, e *Endpoint, path string) {
+	resp, err := probeClient.Get(e.BaseURL + path)
+	if err != nil {
+		if e.Healthy() {
+			c.logger.Warn("endpoint health check failed", zap.String("endpoint", e.BaseURL), zap.Error(err))
+		}
+		e.setHealthy(false)
+		return
+	}
+	defer resp.Body.Close()
+
+	healthy := resp.StatusCode >= 200 && resp.StatusCode < 300
+	if healthy != e.Healthy() {
+		c.logger.Info("endpoint health changed",
+			zap.String("endpoint", e.BaseURL),
+			zap.Bool("healthy", healthy),
+			zap.Int("status", resp.StatusCode),
+		)
+	}
+	e.setHealthy(healthy)
+}
+
+// Close stops any background health-check polling or service-discovery
+// resolving started for this client. It is safe to call on a Client created
+// without a HealthPath or Resolver.
+func (c *Client) Close() error {
+	if c.healthStop != nil {
+		close(c.healthStop)
+		<-c.healthDone
+	}
+	if c.resolveStop != nil {
+		close(c.resolveStop)
+		<-c.resolveDone
+	}
+	return nil
+}
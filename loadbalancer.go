@@ -0,0 +1,119 @@
+package serviceclient
+
+import (
+	"errors"
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/sony/gobreaker"
+)
+
+// errNoAvailableEndpoints is returned by a LoadBalancer when given no
+// candidates to choose from.
+var errNoAvailableEndpoints = errors.New("serviceclient: no available endpoints")
+
+// Endpoint is one of a Client's possibly-many target base URLs. Each
+// Endpoint owns its own circuit breaker, so a single flapping replica trips
+// only its own breaker rather than blocking traffic to the others.
+type Endpoint struct {
+	BaseURL string
+
+	breaker  *gobreaker.CircuitBreaker
+	inFlight int64
+	healthy  int32 // atomic bool; 1 = healthy (default), 0 = out of rotation
+}
+
+func newEndpoint(baseURL string, breaker *gobreaker.CircuitBreaker) *Endpoint {
+	return &Endpoint{BaseURL: baseURL, breaker: breaker, healthy: 1}
+}
+
+// InFlight returns the number of requests currently outstanding against
+// this endpoint.
+func (e *Endpoint) InFlight() int64 {
+	return atomic.LoadInt64(&e.inFlight)
+}
+
+// Healthy reports whether the endpoint's last health probe succeeded. An
+// endpoint with no configured health checks is always healthy.
+func (e *Endpoint) Healthy() bool {
+	return atomic.LoadInt32(&e.healthy) == 1
+}
+
+func (e *Endpoint) setHealthy(healthy bool) {
+	v := int32(0)
+	if healthy {
+		v = 1
+	}
+	atomic.StoreInt32(&e.healthy, v)
+}
+
+// available reports whether the endpoint should be considered by a
+// LoadBalancer: it must be healthy and its circuit breaker must not be open.
+func (e *Endpoint) available() bool {
+	return e.Healthy() && e.breaker.State() != gobreaker.StateOpen
+}
+
+// LoadBalancer selects one endpoint from a set of candidates that a Client
+// has already filtered down to healthy endpoints whose circuit breaker is
+// not open (closed or half-open).
+type LoadBalancer interface {
+	Next(candidates []*Endpoint) (*Endpoint, error)
+}
+
+// roundRobinBalancer cycles through candidates in order.
+type roundRobinBalancer struct {
+	counter uint64
+}
+
+// NewRoundRobinLoadBalancer returns a LoadBalancer that cycles through
+// available endpoints in order.
+func NewRoundRobinLoadBalancer() LoadBalancer {
+	return &roundRobinBalancer{}
+}
+
+func (b *roundRobinBalancer) Next(candidates []*Endpoint) (*Endpoint, error) {
+	if len(candidates) == 0 {
+		return nil, errNoAvailableEndpoints
+	}
+	n := atomic.AddUint64(&b.counter, 1)
+	return candidates[(n-1)%uint64(len(candidates))], nil
+}
+
+// randomBalancer picks a uniformly random candidate.
+type randomBalancer struct{}
+
+// NewRandomLoadBalancer returns a LoadBalancer that picks a uniformly random
+// endpoint from the available candidates.
+func NewRandomLoadBalancer() LoadBalancer {
+	return randomBalancer{}
+}
+
+func (randomBalancer) Next(candidates []*Endpoint) (*Endpoint, error) {
+	if len(candidates) == 0 {
+		return nil, errNoAvailableEndpoints
+	}
+	return candidates[rand.Intn(len(candidates))], nil
+}
+
+// leastConnectionsBalancer picks the candidate with the fewest in-flight
+// requests, breaking ties by order.
+type leastConnectionsBalancer struct{}
+
+// NewLeastConnectionsLoadBalancer returns a LoadBalancer that picks the
+// endpoint with the fewest in-flight requests.
+func NewLeastConnectionsLoadBalancer() LoadBalancer {
+	return leastConnectionsBalancer{}
+}
+
+func (leastConnectionsBalancer) Next(candidates []*Endpoint) (*Endpoint, error) {
+	if len(candidates) == 0 {
+		return nil, errNoAvailableEndpoints
+	}
+	best := candidates[0]
+	for _, e := range candidates[1:] {
+		if e.InFlight() < best.InFlight() {
+			best = e
+		}
+	}
+	return best, nil
+}
@@ -0,0 +1,83 @@
+package serviceclient
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/sony/gobreaker"
+)
+
+func newTestEndpoint(baseURL string) *Endpoint {
+	breaker := gobreaker.NewCircuitBreaker(gobreaker.Settings{Name: baseURL})
+	return newEndpoint(baseURL, breaker)
+}
+
+func TestRoundRobinLoadBalancer(t *testing.T) {
+	lb := NewRoundRobinLoadBalancer()
+	candidates := []*Endpoint{newTestEndpoint("a"), newTestEndpoint("b"), newTestEndpoint("c")}
+
+	var got []string
+	for i := 0; i < 6; i++ {
+		e, err := lb.Next(candidates)
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		got = append(got, e.BaseURL)
+	}
+
+	want := []string{"a", "b", "c", "a", "b", "c"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestRoundRobinLoadBalancerNoCandidates(t *testing.T) {
+	lb := NewRoundRobinLoadBalancer()
+	if _, err := lb.Next(nil); err != errNoAvailableEndpoints {
+		t.Fatalf("err = %v, want errNoAvailableEndpoints", err)
+	}
+}
+
+func TestRandomLoadBalancerPicksFromCandidates(t *testing.T) {
+	lb := NewRandomLoadBalancer()
+	candidates := []*Endpoint{newTestEndpoint("a"), newTestEndpoint("b")}
+
+	valid := map[string]bool{"a": true, "b": true}
+	for i := 0; i < 20; i++ {
+		e, err := lb.Next(candidates)
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if !valid[e.BaseURL] {
+			t.Fatalf("Next() = %q, not in candidates", e.BaseURL)
+		}
+	}
+}
+
+func TestLeastConnectionsLoadBalancer(t *testing.T) {
+	lb := NewLeastConnectionsLoadBalancer()
+	busy := newTestEndpoint("busy")
+	idle := newTestEndpoint("idle")
+	atomic.AddInt64(&busy.inFlight, 5)
+
+	e, err := lb.Next([]*Endpoint{busy, idle})
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if e != idle {
+		t.Fatalf("Next() = %q, want the idle endpoint", e.BaseURL)
+	}
+}
+
+func TestEndpointAvailableReflectsHealthAndBreaker(t *testing.T) {
+	e := newTestEndpoint("a")
+	if !e.available() {
+		t.Fatal("a fresh endpoint should be available")
+	}
+	e.setHealthy(false)
+	if e.available() {
+		t.Fatal("an unhealthy endpoint should not be available")
+	}
+}
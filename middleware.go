@@ -0,0 +1,239 @@
+package serviceclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// RoundTripFunc adapts a function to an http.RoundTripper.
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps an http.RoundTripper with cross-cutting request/response
+// behavior (compression, auth, idempotency, signing, ...). Middlewares are
+// applied in the order passed to Client.Use, so the first middleware is the
+// outermost layer.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// Use installs one or more middlewares around the client's transport. They
+// are applied in reverse so that, per Middleware's doc comment, the first
+// one passed ends up as the outermost layer (the one whose code runs first
+// on the way out and last on the way back).
+func (c *Client) Use(mw ...Middleware) {
+	for i := len(mw) - 1; i >= 0; i-- {
+		c.httpClient.Transport = mw[i](c.httpClient.Transport)
+	}
+}
+
+// gzipWriterPool recycles gzip.Writer instances across requests.
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(io.Discard) },
+}
+
+// GzipMiddleware compresses request bodies with gzip (setting
+// Content-Encoding: gzip) and transparently decompresses gzipped response
+// bodies.
+func GzipMiddleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Body != nil && req.Header.Get("Content-Encoding") == "" {
+				body, err := io.ReadAll(req.Body)
+				req.Body.Close()
+				if err != nil {
+					return nil, fmt.Errorf("read request body for compression: %w", err)
+				}
+
+				var buf bytes.Buffer
+				gw := gzipWriterPool.Get().(*gzip.Writer)
+				gw.Reset(&buf)
+				if _, err := gw.Write(body); err != nil {
+					gzipWriterPool.Put(gw)
+					return nil, fmt.Errorf("gzip request body: %w", err)
+				}
+				if err := gw.Close(); err != nil {
+					gzipWriterPool.Put(gw)
+					return nil, fmt.Errorf("gzip request body: %w", err)
+				}
+				gzipWriterPool.Put(gw)
+
+				req.Body = io.NopCloser(&buf)
+				req.ContentLength = int64(buf.Len())
+				req.Header.Set("Content-Encoding", "gzip")
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+
+			if resp.Header.Get("Content-Encoding") == "gzip" {
+				gr, err := gzip.NewReader(resp.Body)
+				if err != nil {
+					resp.Body.Close()
+					return nil, fmt.Errorf("create gzip reader: %w", err)
+				}
+				resp.Body = &gzipReadCloser{gr: gr, underlying: resp.Body}
+				resp.Header.Del("Content-Encoding")
+				resp.Header.Del("Content-Length")
+				resp.ContentLength = -1
+			}
+
+			return resp, nil
+		})
+	}
+}
+
+// gzipReadCloser closes both the gzip reader and the underlying response
+// body when the caller is done reading.
+type gzipReadCloser struct {
+	gr         *gzip.Reader
+	underlying io.ReadCloser
+}
+
+func (g *gzipReadCloser) Read(p []byte) (int, error) {
+	return g.gr.Read(p)
+}
+
+func (g *gzipReadCloser) Close() error {
+	gErr := g.gr.Close()
+	uErr := g.underlying.Close()
+	if gErr != nil {
+		return gErr
+	}
+	return uErr
+}
+
+// TokenSource supplies bearer/OAuth2 tokens for outgoing requests.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenRefresher is implemented by TokenSources that can discard a cached
+// token and fetch a fresh one, e.g. after the server rejects it with 401.
+type TokenRefresher interface {
+	Refresh(ctx context.Context) error
+}
+
+// BearerAuthMiddleware injects an "Authorization: Bearer <token>" header
+// using ts, and, if ts implements TokenRefresher, refreshes and retries
+// once when the server responds with 401 Unauthorized.
+func BearerAuthMiddleware(ts TokenSource) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			token, err := ts.Token(req.Context())
+			if err != nil {
+				return nil, fmt.Errorf("get auth token: %w", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+
+			refresher, ok := ts.(TokenRefresher)
+			if !ok || resp.StatusCode != http.StatusUnauthorized {
+				return resp, nil
+			}
+			resp.Body.Close()
+
+			if err := refresher.Refresh(req.Context()); err != nil {
+				return nil, fmt.Errorf("refresh auth token: %w", err)
+			}
+			token, err = ts.Token(req.Context())
+			if err != nil {
+				return nil, fmt.Errorf("get auth token after refresh: %w", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			// The first RoundTrip already drained req.Body (if any); get a
+			// fresh copy before resending, since the underlying body can
+			// only be read once.
+			if req.Body != nil {
+				if req.GetBody == nil {
+					return nil, fmt.Errorf("retry request after token refresh: request body is not replayable")
+				}
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, fmt.Errorf("rewind request body after token refresh: %w", err)
+				}
+				req.Body = body
+			}
+
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// IdempotencyKeyMiddleware attaches a randomly generated Idempotency-Key
+// header to POST/PUT/PATCH requests that don't already carry one, so
+// retrying a mutating request is safe to replay against servers that
+// dedupe on that header.
+func IdempotencyKeyMiddleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			switch req.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch:
+				if req.Header.Get("Idempotency-Key") == "" {
+					key, err := newIdempotencyKey()
+					if err != nil {
+						return nil, fmt.Errorf("generate idempotency key: %w", err)
+					}
+					req.Header.Set("Idempotency-Key", key)
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+func newIdempotencyKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	// Format as a UUIDv4-like string for readability in server-side logs.
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// SigningMiddleware signs each request body with HMAC-SHA256 using secret
+// and attaches the hex-encoded signature in the named header, allowing the
+// receiving service to verify the request was not tampered with in
+// transit.
+func SigningMiddleware(secret []byte, header string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			var body []byte
+			if req.Body != nil {
+				b, err := io.ReadAll(req.Body)
+				req.Body.Close()
+				if err != nil {
+					return nil, fmt.Errorf("read request body for signing: %w", err)
+				}
+				body = b
+				req.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			mac := hmac.New(sha256.New, secret)
+			mac.Write(body)
+			req.Header.Set(header, hex.EncodeToString(mac.Sum(nil)))
+
+			return next.RoundTrip(req)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package serviceclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUseAppliesFirstMiddlewareOutermost(t *testing.T) {
+	var order []string
+	tag := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, "enter:"+name)
+				resp, err := next.RoundTrip(req)
+				order = append(order, "leave:"+name)
+				return resp, err
+			})
+		}
+	}
+
+	c := &Client{httpClient: &http.Client{Transport: RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})}}
+	c.Use(tag("first"), tag("second"))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+	if _, err := c.httpClient.Transport.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+
+	want := []string{"enter:first", "enter:second", "leave:second", "leave:first"}
+	if len(order) != len(want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+}
+
+type staticTokenSource struct {
+	token string
+}
+
+func (s *staticTokenSource) Token(ctx context.Context) (string, error) { return s.token, nil }
+
+type refreshingTokenSource struct {
+	staticTokenSource
+	refreshed bool
+}
+
+func (s *refreshingTokenSource) Refresh(ctx context.Context) error {
+	s.refreshed = true
+	s.token = "refreshed-token"
+	return nil
+}
+
+// TestBearerAuthMiddlewareReplaysBodyAfterRefresh guards against the
+// regression where the retried RoundTrip after a 401 reused the same
+// *http.Request whose body had already been drained by the first attempt.
+func TestBearerAuthMiddlewareReplaysBodyAfterRefresh(t *testing.T) {
+	var bodies []string
+	attempt := 0
+	next := RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempt++
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("read request body on attempt %d: %v", attempt, err)
+		}
+		bodies = append(bodies, string(body))
+
+		if attempt == 1 {
+			return &http.Response{StatusCode: http.StatusUnauthorized, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	ts := &refreshingTokenSource{staticTokenSource: staticTokenSource{token: "stale-token"}}
+	rt := BearerAuthMiddleware(ts)(next)
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.invalid", strings.NewReader(`{"hello":"world"}`))
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if !ts.refreshed {
+		t.Fatal("expected Refresh to be called on 401")
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("attempts = %d, want 2", len(bodies))
+	}
+	for i, b := range bodies {
+		if b != `{"hello":"world"}` {
+			t.Fatalf("attempt %d body = %q, want the original JSON body", i+1, b)
+		}
+	}
+}
+
+// TestBearerAuthMiddlewareNonReplayableBodyErrors ensures a body that can't
+// be rewound (no GetBody) fails loudly on retry instead of silently sending
+// an empty body.
+func TestBearerAuthMiddlewareNonReplayableBodyErrors(t *testing.T) {
+	next := RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusUnauthorized, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	ts := &refreshingTokenSource{staticTokenSource: staticTokenSource{token: "stale-token"}}
+	rt := BearerAuthMiddleware(ts)(next)
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.invalid", strings.NewReader("body"))
+	req.GetBody = nil // simulate a non-replayable body (e.g. a plain io.Reader)
+
+	if _, err := rt.RoundTrip(req); err == nil {
+		t.Fatal("expected an error instead of silently resending an empty body")
+	}
+}
+
+func TestIdempotencyKeyMiddlewareSetsKeyOnce(t *testing.T) {
+	var keys []string
+	next := RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		keys = append(keys, req.Header.Get("Idempotency-Key"))
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+	rt := IdempotencyKeyMiddleware()(next)
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.invalid", nil)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	if keys[0] == "" {
+		t.Fatal("expected a generated Idempotency-Key")
+	}
+
+	// A caller-supplied key must not be overwritten.
+	req2, _ := http.NewRequest(http.MethodPost, "http://example.invalid", nil)
+	req2.Header.Set("Idempotency-Key", "caller-key")
+	if _, err := rt.RoundTrip(req2); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	if got := req2.Header.Get("Idempotency-Key"); got != "caller-key" {
+		t.Fatalf("Idempotency-Key = %q, want caller-key to be preserved", got)
+	}
+}
+
+func TestGzipMiddlewareRoundTrip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "gzip" {
+			t.Errorf("request Content-Encoding = %q, want gzip", r.Header.Get("Content-Encoding"))
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: GzipMiddleware()(http.DefaultTransport)}
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("hello world"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+}
@@ -0,0 +1,98 @@
+package serviceclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
+)
+
+// bulkhead caps the number of in-flight requests a Client will allow,
+// queueing callers (up to maxWait) and rejecting them once that budget is
+// exhausted, so a slow downstream can't let unbounded concurrent requests
+// pile up.
+type bulkhead struct {
+	sem     *semaphore.Weighted
+	maxWait time.Duration
+}
+
+func newBulkhead(maxConcurrent int64, maxWait time.Duration) *bulkhead {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+	return &bulkhead{sem: semaphore.NewWeighted(maxConcurrent), maxWait: maxWait}
+}
+
+// acquire blocks until a slot is free, the bulkhead's MaxWaitTime elapses,
+// or ctx is done, returning an error in the latter two cases. The caller
+// must call the returned release func once it is done, but only when err is
+// nil.
+func (b *bulkhead) acquire(ctx context.Context) (release func(), err error) {
+	if b == nil {
+		return func() {}, nil
+	}
+
+	waitCtx := ctx
+	if b.maxWait > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, b.maxWait)
+		defer cancel()
+	}
+
+	if err := b.sem.Acquire(waitCtx, 1); err != nil {
+		return nil, fmt.Errorf("serviceclient: bulkhead saturated: %w", err)
+	}
+	return func() { b.sem.Release(1) }, nil
+}
+
+// rateLimiter wraps golang.org/x/time/rate.Limiter so it can be nil (i.e.
+// disabled) without every call site needing to check.
+type rateLimiter struct {
+	limiter *rate.Limiter
+}
+
+func newRateLimiter(requestsPerSecond float64, burst int) *rateLimiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return &rateLimiter{limiter: rate.NewLimiter(rate.Limit(requestsPerSecond), burst)}
+}
+
+// wait blocks until the limiter admits the caller or ctx is done.
+func (r *rateLimiter) wait(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+	if err := r.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("serviceclient: rate limited: %w", err)
+	}
+	return nil
+}
+
+// resilienceMetrics holds the rejection counters emitted by the bulkhead and
+// rate limiter policies.
+type resilienceMetrics struct {
+	bulkheadRejections  metric.Int64Counter
+	rateLimitRejections metric.Int64Counter
+}
+
+func newResilienceMetrics(meter metric.Meter) resilienceMetrics {
+	bulkheadRejections, _ := meter.Int64Counter(
+		"serviceclient.bulkhead.rejections",
+		metric.WithDescription("Requests rejected because the client-side bulkhead was saturated"),
+	)
+	rateLimitRejections, _ := meter.Int64Counter(
+		"serviceclient.ratelimit.rejections",
+		metric.WithDescription("Requests rejected or abandoned waiting for the client-side rate limiter"),
+	)
+	return resilienceMetrics{
+		bulkheadRejections:  bulkheadRejections,
+		rateLimitRejections: rateLimitRejections,
+	}
+}
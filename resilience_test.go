@@ -0,0 +1,116 @@
+package serviceclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBulkheadRejectsBeyondCapacity(t *testing.T) {
+	b := newBulkhead(1, 20*time.Millisecond)
+
+	release, err := b.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	defer release()
+
+	if _, err := b.acquire(context.Background()); err == nil {
+		t.Fatal("expected the second acquire to be rejected while the bulkhead is saturated")
+	}
+}
+
+func TestBulkheadNilDisabled(t *testing.T) {
+	var b *bulkhead
+	release, err := b.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("nil bulkhead should never reject: %v", err)
+	}
+	release()
+}
+
+func TestBulkheadReleaseFreesSlot(t *testing.T) {
+	b := newBulkhead(1, 0)
+
+	release, err := b.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	release()
+
+	release2, err := b.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release2()
+}
+
+func TestRateLimiterNilDisabled(t *testing.T) {
+	var r *rateLimiter
+	if err := r.wait(context.Background()); err != nil {
+		t.Fatalf("nil rate limiter should never block: %v", err)
+	}
+}
+
+func TestRateLimiterRejectsOnContextDeadline(t *testing.T) {
+	r := newRateLimiter(1, 1)
+	// Consume the single burst token so the next Wait has to queue.
+	if err := r.wait(context.Background()); err != nil {
+		t.Fatalf("first wait: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := r.wait(ctx); err == nil {
+		t.Fatal("expected the rate limiter to reject once the context deadline is tighter than the refill interval")
+	}
+}
+
+func TestBulkheadConcurrentSaturation(t *testing.T) {
+	b := newBulkhead(2, 50*time.Millisecond)
+
+	var wg sync.WaitGroup
+	var rejections int32
+	var mu sync.Mutex
+	var rejErr error
+
+	held := make(chan struct{})
+	release1, err := b.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire 1: %v", err)
+	}
+	release2, err := b.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire 2: %v", err)
+	}
+	close(held)
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-held
+			if _, err := b.acquire(context.Background()); err != nil {
+				mu.Lock()
+				rejections++
+				rejErr = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	release1()
+	release2()
+
+	if rejections == 0 {
+		t.Fatal("expected at least one caller to be rejected while both slots were held")
+	}
+	// acquire wraps the semaphore's error; confirm it came from the
+	// MaxWaitTime deadline on the saturation path rather than asserting
+	// exact text.
+	if !errors.Is(rejErr, context.DeadlineExceeded) {
+		t.Fatalf("rejection err = %v, want it to wrap context.DeadlineExceeded", rejErr)
+	}
+}
@@ -0,0 +1,54 @@
+package serviceclient
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// startResolving begins polling resolver every interval, replacing the
+// client's endpoint list with what it returns. Endpoints whose base URL is
+// unchanged keep their existing circuit breaker (and so its open/closed
+// state); additions get a fresh breaker and removals are dropped.
+func (c *Client) startResolving(resolver Resolver, interval time.Duration) {
+	c.resolveStop = make(chan struct{})
+	c.resolveDone = make(chan struct{})
+
+	go func() {
+		defer close(c.resolveDone)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.resolveStop:
+				return
+			case <-ticker.C:
+				c.refreshEndpoints(resolver)
+			}
+		}
+	}()
+}
+
+func (c *Client) refreshEndpoints(resolver Resolver) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	urls, err := resolver.Resolve(ctx)
+	if err != nil {
+		c.logger.Warn("service discovery resolve failed", zap.Error(err))
+		return
+	}
+
+	c.endpointsMu.Lock()
+	existing := make(map[string]*Endpoint, len(c.endpoints))
+	for _, e := range c.endpoints {
+		existing[e.BaseURL] = e
+	}
+	c.endpoints = buildEndpoints(c.serviceName, urls, c.newBreaker, existing)
+	c.endpointsMu.Unlock()
+
+	c.logger.Debug("service discovery resolved endpoints", zap.Strings("endpoints", urls))
+}
@@ -0,0 +1,172 @@
+package serviceclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy decides whether a request should be retried and, if so, how
+// long to wait before the next attempt. resp is nil when the request failed
+// before a response was received (e.g. a network error). For the buffered
+// methods (Get, Post, ...) its Body has already been read and closed by the
+// time the policy is invoked; for Stream it is still unread and may be handed
+// to the caller. Either way only the status code and headers are usable. A
+// zero delay means "use the client's normal backoff schedule".
+type RetryPolicy func(resp *http.Response, err error) (retry bool, delay time.Duration)
+
+// defaultRetryPolicy classifies errors using errors.Is/As against well-known
+// transient error types instead of matching on err.Error() strings, and
+// honors the Retry-After header on 429/503 responses.
+func defaultRetryPolicy(resp *http.Response, err error) (bool, time.Duration) {
+	if resp != nil {
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				return true, d
+			}
+			return true, 0
+		default:
+			if resp.StatusCode >= 500 {
+				return true, 0
+			}
+		}
+	}
+
+	if err != nil {
+		return isRetryableError(err), 0
+	}
+
+	return false, 0
+}
+
+// isRetryableError reports whether err represents a transient, retryable
+// condition such as a timeout or a connection-level failure. It classifies
+// errors by type rather than by matching error message strings.
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, io.EOF) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return true
+		}
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if urlErr.Timeout() {
+			return true
+		}
+		return isRetryableError(urlErr.Err)
+	}
+
+	var tlsErr *tls.RecordHeaderError
+	if errors.As(err, &tlsErr) {
+		return true
+	}
+
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header value per RFC 7231, which may
+// be expressed either as an integer number of delta-seconds or as an
+// HTTP-date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			return 0, false
+		}
+		return d, true
+	}
+
+	return 0, false
+}
+
+// overridableBackOff wraps a backoff.BackOff so that a single upcoming
+// interval can be overridden, e.g. to honor a server-provided Retry-After
+// duration instead of the normal exponential schedule.
+type overridableBackOff struct {
+	inner    backOff
+	mu       sync.Mutex
+	override time.Duration
+	hasOverr bool
+}
+
+// backOff mirrors github.com/cenkalti/backoff/v4.BackOff to avoid importing
+// it into this file solely for the interface definition.
+type backOff interface {
+	NextBackOff() time.Duration
+	Reset()
+}
+
+func newOverridableBackOff(inner backOff) *overridableBackOff {
+	return &overridableBackOff{inner: inner}
+}
+
+func (o *overridableBackOff) NextBackOff() time.Duration {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.hasOverr {
+		d := o.override
+		o.hasOverr = false
+		return d
+	}
+	return o.inner.NextBackOff()
+}
+
+func (o *overridableBackOff) Reset() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.hasOverr = false
+	o.inner.Reset()
+}
+
+// SetNextInterval forces the next call to NextBackOff to return d instead of
+// following the normal schedule.
+func (o *overridableBackOff) SetNextInterval(d time.Duration) {
+	o.mu.Lock()
+	o.override = d
+	o.hasOverr = true
+	o.mu.Unlock()
+}
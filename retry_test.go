@@ -0,0 +1,135 @@
+package serviceclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantOK  bool
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{name: "empty", value: "", wantOK: false},
+		{name: "delta seconds", value: "5", wantOK: true, wantMin: 5 * time.Second, wantMax: 5 * time.Second},
+		{name: "negative delta seconds", value: "-1", wantOK: false},
+		{name: "http date in the future", value: time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), wantOK: true, wantMin: 8 * time.Second, wantMax: 10 * time.Second},
+		{name: "http date in the past", value: time.Now().Add(-10 * time.Second).UTC().Format(http.TimeFormat), wantOK: false},
+		{name: "garbage", value: "not-a-date", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := parseRetryAfter(tt.value)
+			if ok != tt.wantOK {
+				t.Fatalf("parseRetryAfter(%q) ok = %v, want %v", tt.value, ok, tt.wantOK)
+			}
+			if ok && (d < tt.wantMin || d > tt.wantMax) {
+				t.Fatalf("parseRetryAfter(%q) = %v, want between %v and %v", tt.value, d, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: true},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "unclassified error", err: errors.New("boom"), want: false},
+		{name: "net op error", err: &net.OpError{Op: "dial", Err: errors.New("refused")}, want: true},
+		{name: "url error wrapping op error", err: &url.Error{Op: "Get", URL: "http://x", Err: &net.OpError{Op: "dial", Err: errors.New("refused")}}, want: true},
+		{name: "url error canceled", err: &url.Error{Op: "Get", URL: "http://x", Err: context.Canceled}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(tt.err); got != tt.want {
+				t.Fatalf("isRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultRetryPolicyHonorsRetryAfter(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": []string{"2"}}}
+	retry, delay := defaultRetryPolicy(resp, nil)
+	if !retry {
+		t.Fatal("expected retry for 503")
+	}
+	if delay != 2*time.Second {
+		t.Fatalf("delay = %v, want 2s", delay)
+	}
+}
+
+func TestDefaultRetryPolicyNonRetryableStatus(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusBadRequest}
+	if retry, _ := defaultRetryPolicy(resp, nil); retry {
+		t.Fatal("expected 400 to not be retried")
+	}
+}
+
+// TestOverridableBackOffIsolatedPerInstance guards against the regression
+// where a single overridableBackOff shared across concurrent callers let one
+// caller's Reset/SetNextInterval stomp on another's schedule. Each goroutine
+// here gets its own instance (as client.go's newRetryBackoff factory now
+// guarantees), so their overrides and elapsed-time clocks must not interact.
+func TestOverridableBackOffIsolatedPerInstance(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b := newOverridableBackOff(newTestExpBackOff())
+			// +2ms keeps want distinct from fakeBackOff's fixed 1ms
+			// fallback, so a reused override can't coincidentally match it.
+			want := time.Duration(i+2) * time.Millisecond
+			b.SetNextInterval(want)
+			if got := b.NextBackOff(); got != want {
+				t.Errorf("goroutine %d: NextBackOff() = %v, want %v", i, got, want)
+			}
+			// The override is one-shot; the next call must fall back to the
+			// inner schedule, not silently re-apply someone else's override.
+			if got := b.NextBackOff(); got == want && want != 0 {
+				t.Errorf("goroutine %d: override was reused instead of being consumed once", i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestOverridableBackOffResetClearsOverride(t *testing.T) {
+	b := newOverridableBackOff(newTestExpBackOff())
+	b.SetNextInterval(time.Hour)
+	b.Reset()
+	if got := b.NextBackOff(); got == time.Hour {
+		t.Fatal("Reset did not clear a pending override")
+	}
+}
+
+func newTestExpBackOff() backOff {
+	return &fakeBackOff{}
+}
+
+// fakeBackOff is a minimal backOff whose NextBackOff is stable and cheap,
+// keeping these tests independent of cenkalti/backoff's jitter/randomization.
+type fakeBackOff struct {
+	resets int
+}
+
+func (f *fakeBackOff) NextBackOff() time.Duration { return time.Millisecond }
+func (f *fakeBackOff) Reset()                     { f.resets++ }
@@ -0,0 +1,171 @@
+package serviceclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/cenkalti/backoff/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// StreamResponse is like Response but leaves the body unread, so large
+// payloads don't have to be buffered into memory. The caller must read and
+// Close Body.
+type StreamResponse struct {
+	StatusCode int
+	Headers    http.Header
+	Body       io.ReadCloser
+}
+
+// BodyFactory produces a fresh request body for each attempt. Pass one to
+// Stream/Upload instead of a plain io.Reader when the body can't be
+// rewound with io.Seeker, so retries can still resend it from the start.
+type BodyFactory func() (io.ReadCloser, error)
+
+// errStreamBodyConsumed is returned when a retryable error or status is hit
+// after a non-seekable, non-factory body has already been sent once, since
+// there is no way to safely resend it.
+var errStreamBodyConsumed = errors.New("serviceclient: request body already consumed and cannot be retried")
+
+// openStreamBody resolves body into a fresh io.ReadCloser for the given
+// attempt (0-indexed). body may be nil, a BodyFactory, an io.ReadSeeker
+// (rewound on attempts after the first), or a plain io.Reader/io.ReadCloser
+// (only usable on the first attempt).
+func openStreamBody(body interface{}, attempt int) (io.ReadCloser, error) {
+	switch b := body.(type) {
+	case nil:
+		return nil, nil
+	case BodyFactory:
+		return b()
+	case io.ReadSeeker:
+		if attempt > 0 {
+			if _, err := b.Seek(0, io.SeekStart); err != nil {
+				return nil, fmt.Errorf("serviceclient: rewind request body for retry: %w", err)
+			}
+		}
+		return io.NopCloser(b), nil
+	case io.ReadCloser:
+		if attempt > 0 {
+			return nil, errStreamBodyConsumed
+		}
+		return b, nil
+	case io.Reader:
+		if attempt > 0 {
+			return nil, errStreamBodyConsumed
+		}
+		return io.NopCloser(b), nil
+	default:
+		return nil, fmt.Errorf("serviceclient: unsupported stream body type %T", body)
+	}
+}
+
+// Stream performs a request without buffering the response body into
+// memory. body may be nil, an io.Reader, an io.ReadSeeker, or a
+// BodyFactory; see openStreamBody for how each is retried. On success the
+// caller owns StreamResponse.Body and must Close it.
+func (c *Client) Stream(ctx context.Context, method, path string, body interface{}, headers map[string]string) (*StreamResponse, error) {
+	endpoint, err := c.selectEndpoint()
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(&endpoint.inFlight, 1)
+	defer atomic.AddInt64(&endpoint.inFlight, -1)
+
+	if err := c.rateLimiter.wait(ctx); err != nil {
+		c.metrics.rateLimitRejections.Add(ctx, 1)
+		return nil, err
+	}
+	release, err := c.bulkhead.acquire(ctx)
+	if err != nil {
+		c.metrics.bulkheadRejections.Add(ctx, 1)
+		return nil, err
+	}
+	defer release()
+
+	url := endpoint.BaseURL + path
+	ctx, span := c.tracer.Start(ctx, fmt.Sprintf("%s %s (stream)", method, path),
+		trace.WithAttributes(
+			attribute.String("http.method", method),
+			attribute.String("http.url", url),
+			attribute.String("service.name", c.serviceName),
+		))
+	defer span.End()
+
+	attempt := 0
+	retryBackoff := c.newRetryBackoff()
+	var resp *StreamResponse
+	err = backoff.Retry(func() error {
+		bodyReader, openErr := openStreamBody(body, attempt)
+		attempt++
+		if openErr != nil {
+			return backoff.Permanent(openErr)
+		}
+
+		req, reqErr := http.NewRequestWithContext(ctx, method, url, bodyReader)
+		if reqErr != nil {
+			return backoff.Permanent(fmt.Errorf("create request: %w", reqErr))
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		// Same breaker/retry verdict logic executeOnce uses: c.retryPolicy
+		// alone decides success/failure, not a hardcoded status class. The
+		// response body is left unread here (unlike executeOnce) so the
+		// caller can stream it; it's only closed below when not accepted.
+		value, httpResp, retry, delay, execErr := executeWithVerdict(endpoint.breaker, c.retryPolicy, func() (*http.Response, interface{}, error) {
+			hr, doErr := c.httpClient.Do(req)
+			if doErr != nil {
+				return nil, nil, doErr
+			}
+			return hr, hr, nil
+		})
+
+		if execErr == nil {
+			hr := value.(*http.Response)
+			resp = &StreamResponse{StatusCode: hr.StatusCode, Headers: hr.Header, Body: hr.Body}
+			return nil
+		}
+
+		// Either an error occurred or the policy wants to retry; either
+		// way this response isn't being handed to the caller, so close
+		// its unread body.
+		if httpResp != nil {
+			httpResp.Body.Close()
+		}
+		span.RecordError(execErr)
+		if !retry {
+			return backoff.Permanent(execErr)
+		}
+		if delay > 0 {
+			retryBackoff.SetNextInterval(delay)
+		}
+		return execErr
+	}, backoff.WithContext(retryBackoff, ctx))
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.logger.Error("stream request failed", zap.Error(err))
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	span.SetStatus(codes.Ok, "success")
+
+	return resp, nil
+}
+
+// Upload performs a POST whose request body is streamed from body rather
+// than marshalled up-front, for uploads too large to hold in memory. See
+// Stream for how body is retried.
+func (c *Client) Upload(ctx context.Context, path string, body interface{}, headers map[string]string) (*StreamResponse, error) {
+	return c.Stream(ctx, http.MethodPost, path, body, headers)
+}
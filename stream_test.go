@@ -0,0 +1,152 @@
+package serviceclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOpenStreamBodyNil(t *testing.T) {
+	rc, err := openStreamBody(nil, 0)
+	if err != nil || rc != nil {
+		t.Fatalf("openStreamBody(nil) = %v, %v; want nil, nil", rc, err)
+	}
+}
+
+func TestOpenStreamBodyFactoryCalledEveryAttempt(t *testing.T) {
+	calls := 0
+	factory := BodyFactory(func() (io.ReadCloser, error) {
+		calls++
+		return io.NopCloser(strings.NewReader("body")), nil
+	})
+
+	for attempt := 0; attempt < 3; attempt++ {
+		if _, err := openStreamBody(factory, attempt); err != nil {
+			t.Fatalf("attempt %d: %v", attempt, err)
+		}
+	}
+	if calls != 3 {
+		t.Fatalf("factory called %d times, want 3", calls)
+	}
+}
+
+func TestOpenStreamBodySeekerRewoundOnRetry(t *testing.T) {
+	seeker := bytes.NewReader([]byte("payload"))
+
+	rc, err := openStreamBody(seeker, 0)
+	if err != nil {
+		t.Fatalf("attempt 0: %v", err)
+	}
+	if _, err := io.ReadAll(rc); err != nil {
+		t.Fatalf("drain attempt 0: %v", err)
+	}
+
+	rc, err = openStreamBody(seeker, 1)
+	if err != nil {
+		t.Fatalf("attempt 1: %v", err)
+	}
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("read attempt 1: %v", err)
+	}
+	if string(got) != "payload" {
+		t.Fatalf("attempt 1 body = %q, want the seeker rewound to the start", got)
+	}
+}
+
+func TestOpenStreamBodyPlainReaderNotReplayable(t *testing.T) {
+	// bytes.Buffer is an io.Reader but, unlike strings.Reader, not an
+	// io.ReadSeeker, so it exercises the single-use branch of the switch.
+	body := bytes.NewBufferString("once")
+	if _, err := openStreamBody(body, 0); err != nil {
+		t.Fatalf("attempt 0: %v", err)
+	}
+	if _, err := openStreamBody(body, 1); !errors.Is(err, errStreamBodyConsumed) {
+		t.Fatalf("attempt 1 err = %v, want errStreamBodyConsumed", err)
+	}
+}
+
+// TestStreamRetriesWithBodyFactory guards against Stream regressing the same
+// way doRequest did: a BodyFactory must be invoked fresh on every retry of a
+// 503. TestStreamRetryPolicyDecidesBreakerVerdict covers the circuit breaker's
+// verdict coming from RetryPolicy rather than a hardcoded status class.
+func TestStreamRetriesWithBodyFactory(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("attempt body = %q, want payload", body)
+		}
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv.URL, nil)
+	factory := BodyFactory(func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("payload")), nil
+	})
+
+	resp, err := c.Stream(context.Background(), http.MethodPost, "/", factory, nil)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if atomic.LoadInt32(&attempts) != 2 {
+		t.Fatalf("attempts = %d, want 2", attempts)
+	}
+}
+
+// TestStreamRetryPolicyDecidesBreakerVerdict guards against stream.go's
+// breaker.Execute hardcoding >=500/429 independently of RetryPolicy: a
+// custom policy that retries a status outside that set (408) must both be
+// retried and recorded as a breaker failure, and one the policy declines
+// must be handed back as a usable StreamResponse.
+func TestStreamRetryPolicyDecidesBreakerVerdict(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusRequestTimeout)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv.URL, func(cfg *Config) {
+		cfg.RetryPolicy = func(resp *http.Response, err error) (bool, time.Duration) {
+			if resp != nil && resp.StatusCode == http.StatusRequestTimeout {
+				return true, 0
+			}
+			return false, 0
+		}
+	})
+
+	resp, err := c.Stream(context.Background(), http.MethodGet, "/", nil, nil)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if atomic.LoadInt32(&attempts) != 2 {
+		t.Fatalf("attempts = %d, want the 408 to be retried", attempts)
+	}
+	if got := c.endpoints[0].breaker.Counts().TotalFailures; got != 1 {
+		t.Fatalf("breaker TotalFailures = %d, want the retried 408 recorded as 1 failure", got)
+	}
+}